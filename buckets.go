@@ -0,0 +1,130 @@
+package histogram
+
+import (
+	"math"
+	"sort"
+)
+
+// Buckets is a Prometheus-compatible cumulative bucketed histogram.
+//
+// It maintains cumulative observation counts across a fixed set of upper
+// bounds plus +Inf, along with the running sum and count, in the same
+// shape Prometheus exposes for its histogram metric type.
+//
+// It cannot be used from concurrently running goroutines without
+// external synchronization.
+type Buckets struct {
+	upperBounds []float64
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+// NewBuckets returns new Buckets with the given upper bounds.
+//
+// bounds is sorted and a final +Inf bucket is appended automatically.
+func NewBuckets(bounds []float64) *Buckets {
+	upperBounds := append([]float64{}, bounds...)
+	sort.Float64s(upperBounds)
+	upperBounds = append(upperBounds, infPos)
+
+	return &Buckets{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+// LinearBuckets returns n bucket upper bounds starting at start with a
+// constant width, for use with NewBuckets.
+func LinearBuckets(start, width float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start + float64(i)*width
+	}
+	return bounds
+}
+
+// ExponentialBuckets returns n bucket upper bounds starting at start and
+// growing by factor each step, for use with NewBuckets.
+func ExponentialBuckets(start, factor float64, n int) []float64 {
+	bounds := make([]float64, n)
+	v := start
+	for i := range bounds {
+		bounds[i] = v
+		v *= factor
+	}
+	return bounds
+}
+
+// Observe updates b with v.
+func (b *Buckets) Observe(v float64) {
+	b.sum += v
+	b.count++
+	for i, upperBound := range b.upperBounds {
+		if v <= upperBound {
+			b.counts[i]++
+		}
+	}
+}
+
+// BucketsSnapshot is a point-in-time snapshot of a Buckets histogram
+// suitable for serving via an HTTP exporter.
+type BucketsSnapshot struct {
+	// CumulativeCounts holds the cumulative number of observations
+	// less than or equal to the corresponding entry in UpperBounds.
+	CumulativeCounts []uint64
+
+	// UpperBounds holds the bucket upper bounds, including the final
+	// +Inf bucket.
+	UpperBounds []float64
+
+	// Sum is the sum of all the observed values.
+	Sum float64
+
+	// Count is the total number of observed values.
+	Count uint64
+}
+
+// Snapshot returns a point-in-time snapshot of b.
+func (b *Buckets) Snapshot() BucketsSnapshot {
+	return BucketsSnapshot{
+		CumulativeCounts: append([]uint64{}, b.counts...),
+		UpperBounds:      append([]float64{}, b.upperBounds...),
+		Sum:              b.sum,
+		Count:            b.count,
+	}
+}
+
+// QuantileFromBuckets returns the quantile value for the given phi from
+// the given snapshot, matching Prometheus's histogram_quantile function.
+func QuantileFromBuckets(snapshot BucketsSnapshot, phi float64) float64 {
+	if snapshot.Count == 0 || math.IsNaN(phi) {
+		return nan
+	}
+	if phi <= 0 {
+		return infNeg
+	}
+	if phi >= 1 {
+		return snapshot.UpperBounds[len(snapshot.UpperBounds)-1]
+	}
+
+	target := phi * float64(snapshot.Count)
+	var prevCount uint64
+	prevBound := 0.0
+	for i, upperBound := range snapshot.UpperBounds {
+		count := snapshot.CumulativeCounts[i]
+		if float64(count) >= target {
+			if math.IsInf(upperBound, 1) {
+				return prevBound
+			}
+			if count == prevCount {
+				return upperBound
+			}
+			frac := (target - float64(prevCount)) / float64(count-prevCount)
+			return prevBound + frac*(upperBound-prevBound)
+		}
+		prevCount = count
+		prevBound = upperBound
+	}
+	return snapshot.UpperBounds[len(snapshot.UpperBounds)-1]
+}
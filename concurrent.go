@@ -0,0 +1,86 @@
+package histogram
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/valyala/fastrand"
+)
+
+// Concurrent is a histogram that can be used from concurrently running
+// goroutines without external synchronization.
+//
+// It stripes observations across GOMAXPROCS per-shard Fast histograms,
+// picking a shard on each Observe via fastrand's lock-free, per-goroutine
+// generator, so Observe only contends with goroutines that happen to
+// land on the same shard instead of serializing through a single global
+// lock or a single shared counter.
+type Concurrent struct {
+	shards []concurrentShard
+}
+
+type concurrentShard struct {
+	mu sync.Mutex
+	f  *Fast
+}
+
+// NewConcurrent returns new Concurrent histogram.
+func NewConcurrent() *Concurrent {
+	shards := make([]concurrentShard, runtime.GOMAXPROCS(0))
+	for i := range shards {
+		shards[i].f = NewFast()
+	}
+	return &Concurrent{
+		shards: shards,
+	}
+}
+
+// Reset resets c.
+func (c *Concurrent) Reset() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.f.Reset()
+		s.mu.Unlock()
+	}
+}
+
+// Observe updates c with v.
+func (c *Concurrent) Observe(v float64) {
+	idx := fastrand.Uint32n(uint32(len(c.shards)))
+	s := &c.shards[idx]
+	s.mu.Lock()
+	s.f.Update(v)
+	s.mu.Unlock()
+}
+
+// Quantile returns the quantile value for the given phi.
+func (c *Concurrent) Quantile(phi float64) float64 {
+	fs := c.snapshot()
+	return Quantile(fs, phi)
+}
+
+// Quantiles appends quantile values to dst for the given phis.
+func (c *Concurrent) Quantiles(dst, phis []float64) []float64 {
+	fs := c.snapshot()
+	return Quantiles(fs, dst, phis)
+}
+
+// snapshot returns a deep copy of the per-shard histograms, so the
+// returned Fast values can be read after the shard locks are released
+// without racing with concurrent Observe calls.
+func (c *Concurrent) snapshot() []*Fast {
+	fs := make([]*Fast, len(c.shards))
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		fs[i] = &Fast{
+			max:   s.f.max,
+			min:   s.f.min,
+			count: s.f.count,
+			a:     append([]float64(nil), s.f.a...),
+		}
+		s.mu.Unlock()
+	}
+	return fs
+}
@@ -134,36 +134,101 @@ func PutFast(f *Fast) {
 
 var fastPool sync.Pool
 
+// Quantile returns the quantile value for the given phi across all the fs.
+//
+// Each f in fs is treated as a weighted sample of its true underlying
+// stream: since f.a may be a reservoir of at most maxSamples out of
+// f.count observed values, every sample in f.a is given the weight
+// f.count/len(f.a). This keeps the merged quantile from being biased
+// towards histograms that happened to not overflow their reservoir yet.
 func Quantile(fs []*Fast, phi float64) float64 {
-	t := combine(fs)
-	return t.quantile(phi)
+	c := combine(fs)
+	return c.quantile(phi)
 }
 
+// Quantiles appends quantile values to dst for the given phis across all the fs.
+//
+// See Quantile for details on how fs are merged.
 func Quantiles(fs []*Fast, dst, phis []float64) []float64 {
-	t := combine(fs)
-	return t.quantiles(dst, phis)
+	c := combine(fs)
+	return c.quantiles(dst, phis)
+}
+
+// weightedSample is a single sample with the weight of the reservoir it
+// came from.
+type weightedSample struct {
+	v      float64
+	weight float64
 }
 
-func combine(fs []*Fast) Fast {
+// combined is a weighted merge of multiple Fast histograms.
+type combined struct {
+	samples     []weightedSample
+	totalWeight float64
+	min         float64
+	max         float64
+}
+
+func combine(fs []*Fast) combined {
 	n := 0
 	for _, f := range fs {
 		n += len(f.a)
 	}
 
-	var t Fast
-	t.Reset()
-	t.tmp = make([]float64, 0, n)
+	c := combined{
+		samples: make([]weightedSample, 0, n),
+		min:     infPos,
+		max:     infNeg,
+	}
 
 	for _, f := range fs {
-		t.tmp = append(t.tmp, f.a...)
-		if t.max < f.max {
-			t.max = f.max
+		if len(f.a) == 0 {
+			continue
 		}
-		if t.min > f.min {
-			t.min = f.min
+		weight := float64(f.count) / float64(len(f.a))
+		for _, v := range f.a {
+			c.samples = append(c.samples, weightedSample{v: v, weight: weight})
+		}
+		c.totalWeight += float64(f.count)
+		if c.max < f.max {
+			c.max = f.max
+		}
+		if c.min > f.min {
+			c.min = f.min
 		}
 	}
-	sort.Float64s(t.tmp)
+	sort.Slice(c.samples, func(i, j int) bool {
+		return c.samples[i].v < c.samples[j].v
+	})
+
+	return c
+}
 
-	return t
+func (c *combined) quantile(phi float64) float64 {
+	if len(c.samples) == 0 || math.IsNaN(phi) {
+		return nan
+	}
+	if phi <= 0 {
+		return c.min
+	}
+	if phi >= 1 {
+		return c.max
+	}
+
+	target := phi * c.totalWeight
+	var cum float64
+	for _, s := range c.samples {
+		cum += s.weight
+		if cum >= target {
+			return s.v
+		}
+	}
+	return c.samples[len(c.samples)-1].v
+}
+
+func (c *combined) quantiles(dst, phis []float64) []float64 {
+	for _, phi := range phis {
+		dst = append(dst, c.quantile(phi))
+	}
+	return dst
 }
@@ -105,3 +105,25 @@ func TestCombine(t *testing.T) {
 		t.Fatal(qs)
 	}
 }
+
+func TestCombineWeighted(t *testing.T) {
+	small := GetFast()
+	defer PutFast(small)
+
+	big := GetFast()
+	defer PutFast(big)
+
+	const bigSamples = 10000000
+	for i := 0; i < 500; i++ {
+		small.Update(1)
+	}
+	for i := 0; i < bigSamples; i++ {
+		big.Update(float64(i))
+	}
+
+	q50 := Quantile([]*Fast{small, big}, 0.5)
+	bigMedian := float64(bigSamples-1) / 2
+	if q50 < bigMedian*0.9 || q50 > bigMedian*1.1 {
+		t.Fatalf("unexpected weighted median; got %v; want a value close to %v", q50, bigMedian)
+	}
+}
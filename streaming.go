@@ -0,0 +1,147 @@
+package histogram
+
+import "sort"
+
+// streamingBin is a single {mean, count} bin used by Streaming.
+type streamingBin struct {
+	mean  float64
+	count uint64
+}
+
+// Streaming is a streaming histogram based on the BigML/Ben-Haim-Tom-Tov
+// bin-merging algorithm.
+//
+// Unlike Fast, which keeps a bounded reservoir of raw samples, Streaming
+// keeps at most maxBins (as passed to NewStreaming) ordered {mean, count}
+// bins, merging the closest pair whenever the bin count would exceed
+// maxBins. This gives bounded memory usage with better tail accuracy than
+// a reservoir, and allows for a Merge across shards that keeps the total
+// count.
+//
+// It cannot be used from concurrently running goroutines without
+// external synchronization.
+type Streaming struct {
+	maxBins int
+	bins    []streamingBin
+	count   uint64
+	sum     float64
+}
+
+// NewStreaming returns new Streaming histogram with at most maxBins bins.
+func NewStreaming(maxBins int) *Streaming {
+	if maxBins <= 0 {
+		maxBins = 1
+	}
+	s := &Streaming{
+		maxBins: maxBins,
+	}
+	s.Reset()
+	return s
+}
+
+// Reset resets s.
+func (s *Streaming) Reset() {
+	if len(s.bins) > 0 {
+		s.bins = s.bins[:0]
+	} else {
+		s.bins = nil
+	}
+	s.count = 0
+	s.sum = 0
+}
+
+// Update updates s with v.
+func (s *Streaming) Update(v float64) {
+	s.insert(streamingBin{mean: v, count: 1})
+	s.count++
+	s.sum += v
+	s.reduce()
+}
+
+// insert inserts b into s.bins keeping it sorted by mean.
+func (s *Streaming) insert(b streamingBin) {
+	i := sort.Search(len(s.bins), func(i int) bool {
+		return s.bins[i].mean >= b.mean
+	})
+	s.bins = append(s.bins, streamingBin{})
+	copy(s.bins[i+1:], s.bins[i:])
+	s.bins[i] = b
+}
+
+// reduce merges the closest adjacent pair of bins until len(s.bins) <= s.maxBins.
+func (s *Streaming) reduce() {
+	for len(s.bins) > s.maxBins {
+		minIdx := 0
+		minGap := infPos
+		for i := 0; i < len(s.bins)-1; i++ {
+			gap := s.bins[i+1].mean - s.bins[i].mean
+			if gap < minGap {
+				minGap = gap
+				minIdx = i
+			}
+		}
+		a, b := s.bins[minIdx], s.bins[minIdx+1]
+		merged := streamingBin{
+			count: a.count + b.count,
+			mean:  (a.mean*float64(a.count) + b.mean*float64(b.count)) / float64(a.count+b.count),
+		}
+		s.bins[minIdx] = merged
+		s.bins = append(s.bins[:minIdx+1], s.bins[minIdx+2:]...)
+	}
+}
+
+// Quantile returns the quantile value for the given phi.
+func (s *Streaming) Quantile(phi float64) float64 {
+	if len(s.bins) == 0 || phi != phi {
+		return nan
+	}
+	if phi <= 0 {
+		return s.bins[0].mean
+	}
+	if phi >= 1 {
+		return s.bins[len(s.bins)-1].mean
+	}
+
+	target := phi * float64(s.count)
+	var cum float64
+	for i, b := range s.bins {
+		prevCum := cum
+		cum += float64(b.count)
+		if cum >= target {
+			if i == 0 {
+				return b.mean
+			}
+			prev := s.bins[i-1]
+			// Linearly interpolate between the previous and the current
+			// bin means across the cumulative count range they span.
+			frac := (target - prevCum) / float64(b.count)
+			return prev.mean + frac*(b.mean-prev.mean)
+		}
+	}
+	return s.bins[len(s.bins)-1].mean
+}
+
+// Sum returns the sum of all the values passed to Update.
+func (s *Streaming) Sum() float64 {
+	return s.sum
+}
+
+// Count returns the number of values passed to Update.
+func (s *Streaming) Count() uint64 {
+	return s.count
+}
+
+// Merge merges other into s.
+//
+// Merge keeps the exact total count and sum of both histograms. The
+// merged bins are reduced back to maxBins after every Merge, so merging
+// the same histograms in a different grouping may yield different bins.
+func (s *Streaming) Merge(other *Streaming) {
+	s.bins = append(s.bins, other.bins...)
+	sort.Slice(s.bins, func(i, j int) bool {
+		return s.bins[i].mean < s.bins[j].mean
+	})
+	s.count += other.count
+	s.sum += other.sum
+	s.reduce()
+}
@@ -0,0 +1,197 @@
+package histogram
+
+import (
+	"math"
+	"sort"
+)
+
+// targetedSample is a single {value, g, delta} tuple tracked by Targeted,
+// as described in the Cormode-Korn-Muthukrishnan-Srivastava streaming
+// quantile paper ("Effective Computation of Biased Quantiles over Data
+// Streams").
+type targetedSample struct {
+	v     float64
+	g     uint64
+	delta uint64
+}
+
+// Targeted is a biased-quantile estimator with configurable per-quantile
+// epsilon error bounds.
+//
+// Unlike Fast, which keeps a bounded reservoir of raw samples, Targeted
+// gives guaranteed error bounds for a fixed set of target quantiles,
+// which makes it suitable for high quantiles such as p99 or p999 that
+// the reservoir cannot reliably estimate.
+//
+// It cannot be used from concurrently running goroutines without
+// external synchronization.
+type Targeted struct {
+	targets map[float64]float64
+
+	samples []targetedSample
+	n       uint64
+}
+
+// NewTargeted returns new Targeted histogram for the given targets.
+//
+// targets maps the quantile phi to its allowed epsilon error, for example
+// NewTargeted(map[float64]float64{0.5: 0.01, 0.99: 0.001}).
+func NewTargeted(targets map[float64]float64) *Targeted {
+	t := &Targeted{
+		targets: targets,
+	}
+	t.Reset()
+	return t
+}
+
+// Reset resets t.
+func (t *Targeted) Reset() {
+	if len(t.samples) > 0 {
+		t.samples = t.samples[:0]
+	} else {
+		t.samples = nil
+	}
+	t.n = 0
+}
+
+// Insert inserts v into t.
+func (t *Targeted) Insert(v float64) {
+	i := t.search(v)
+
+	var delta uint64
+	if i == 0 || i == len(t.samples) {
+		delta = 0
+	} else if e := uint64(t.epsilon(t.rankBefore(i))); e > 0 {
+		delta = e - 1
+	}
+
+	s := targetedSample{v: v, g: 1, delta: delta}
+	t.samples = append(t.samples, targetedSample{})
+	copy(t.samples[i+1:], t.samples[i:])
+	t.samples[i] = s
+	t.n++
+
+	if t.n%50 == 0 {
+		t.compress()
+	}
+}
+
+// search returns the index of the first sample >= v.
+func (t *Targeted) search(v float64) int {
+	lo, hi := 0, len(t.samples)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if t.samples[mid].v < v {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// rankBefore returns the cumulative g, i.e. the estimated rank, of the
+// samples preceding index i.
+func (t *Targeted) rankBefore(i int) uint64 {
+	var r uint64
+	for _, s := range t.samples[:i] {
+		r += s.g
+	}
+	return r
+}
+
+// epsilon returns f(r,n), the minimum of the CKMS invariant functions
+// across all the targets, bounding the allowed (g+delta) for a tuple at
+// rank r.
+func (t *Targeted) epsilon(r uint64) float64 {
+	n := float64(t.n)
+	rf := float64(r)
+	minF := infPos
+	for phi, eps := range t.targets {
+		var f float64
+		if rf <= phi*n {
+			f = 2 * eps * rf / phi
+		} else {
+			f = 2 * eps * (n - rf) / (1 - phi)
+		}
+		if f < minF {
+			minF = f
+		}
+	}
+	if math.IsInf(minF, 1) {
+		return 0
+	}
+	return minF
+}
+
+// compress merges samples whose combined g+delta still satisfies the
+// error bound for their rank, scanning right to left.
+//
+// The scan keeps a running "current kept sample" x (at position xi) and
+// considers merging its left neighbor c into it. Because x is always
+// the most recently finalized sample rather than one still being
+// decided, a merge never needs to be re-examined or re-based the way a
+// left-to-right cascade would: once c is folded into x, the loop simply
+// continues leftwards with r adjusted by c.g, matching the perks/CKMS
+// reference implementation.
+func (t *Targeted) compress() {
+	if len(t.samples) < 2 {
+		return
+	}
+
+	x := t.samples[len(t.samples)-1]
+	xi := len(t.samples) - 1
+	r := t.n - 1 - x.g
+
+	for i := len(t.samples) - 2; i >= 0; i-- {
+		c := t.samples[i]
+		if c.g+x.g+x.delta <= uint64(t.epsilon(r)) {
+			x.g += c.g
+			t.samples[xi] = x
+			t.samples = append(t.samples[:i], t.samples[i+1:]...)
+			xi--
+		} else {
+			x = c
+			xi = i
+		}
+		r -= c.g
+	}
+}
+
+// Query returns the estimated value for the given phi.
+//
+// phi must be one of the targets passed to NewTargeted for the returned
+// value to satisfy the configured epsilon error bound.
+func (t *Targeted) Query(phi float64) float64 {
+	if len(t.samples) == 0 {
+		return nan
+	}
+
+	rank := uint64(phi*float64(t.n) + 0.5)
+	eps := t.epsilon(rank)
+
+	var r uint64
+	for i, s := range t.samples {
+		r += s.g
+		if r+s.delta > rank+uint64(eps/2) {
+			return t.samples[i].v
+		}
+	}
+	return t.samples[len(t.samples)-1].v
+}
+
+// Merge merges other into t.
+//
+// The tuples of other are spliced into t preserving their g and delta,
+// rather than re-inserting each value as a fresh singleton, so the
+// combined count and the error bounds of the originals survive the
+// merge.
+func (t *Targeted) Merge(other *Targeted) {
+	t.samples = append(t.samples, other.samples...)
+	sort.Slice(t.samples, func(i, j int) bool {
+		return t.samples[i].v < t.samples[j].v
+	})
+	t.n += other.n
+
+	t.compress()
+}
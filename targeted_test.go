@@ -0,0 +1,57 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTargetedQuantileAccuracy(t *testing.T) {
+	targets := map[float64]float64{0.5: 0.01, 0.9: 0.01, 0.99: 0.001}
+	tr := NewTargeted(targets)
+
+	rng := rand.New(rand.NewSource(1))
+	const n = 100000
+	values := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v := rng.NormFloat64()*100 + 500
+		values[i] = v
+		tr.Insert(v)
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	for phi, eps := range targets {
+		q := tr.Query(phi)
+		observedRank := float64(sort.SearchFloat64s(sorted, q))
+		wantRank := phi * n
+		maxErr := eps * n
+		if math.Abs(observedRank-wantRank) > maxErr {
+			t.Fatalf("phi=%v: observed rank %v too far from target rank %v; eps=%v allows at most %v",
+				phi, observedRank, wantRank, eps, maxErr)
+		}
+	}
+}
+
+func TestTargetedMerge(t *testing.T) {
+	targets := map[float64]float64{0.5: 0.01}
+
+	t1 := NewTargeted(targets)
+	t2 := NewTargeted(targets)
+
+	rng := rand.New(rand.NewSource(2))
+	const n = 50000
+	for i := 0; i < n; i++ {
+		t1.Insert(rng.NormFloat64()*100 + 500)
+	}
+	for i := 0; i < n; i++ {
+		t2.Insert(rng.NormFloat64()*100 + 500)
+	}
+
+	t1.Merge(t2)
+	if t1.n != 2*n {
+		t.Fatalf("unexpected count after merge; got %d; want %d", t1.n, 2*n)
+	}
+}
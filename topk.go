@@ -0,0 +1,135 @@
+package histogram
+
+// Entry is a single tracked value and its estimated count, as returned
+// by TopK.Top.
+type Entry struct {
+	Value float64
+	Count uint64
+
+	// Error is the maximum amount Count may overestimate the true count
+	// by.
+	Error uint64
+
+	// Guaranteed is true when Count-Error > n/k, where n is the total
+	// number of values observed by the TopK that produced this entry.
+	// Such an entry is guaranteed to be a true heavy hitter.
+	Guaranteed bool
+}
+
+// TopK tracks the approximate top-K most frequent values using the
+// Metwally-Agrawal-Abbadi Space-Saving algorithm.
+//
+// It cannot be used from concurrently running goroutines without
+// external synchronization.
+type TopK struct {
+	k       int
+	entries []Entry
+	n       uint64
+}
+
+// NewTopK returns new TopK tracking at most k values.
+func NewTopK(k int) *TopK {
+	if k <= 0 {
+		k = 1
+	}
+	t := &TopK{
+		k: k,
+	}
+	t.Reset()
+	return t
+}
+
+// Reset resets t.
+func (t *TopK) Reset() {
+	if len(t.entries) > 0 {
+		t.entries = t.entries[:0]
+	} else {
+		t.entries = nil
+	}
+	t.n = 0
+}
+
+// Observe updates t with v.
+func (t *TopK) Observe(v float64) {
+	t.n++
+
+	for i := range t.entries {
+		if t.entries[i].Value == v {
+			t.entries[i].Count++
+			return
+		}
+	}
+
+	if len(t.entries) < t.k {
+		t.entries = append(t.entries, Entry{Value: v, Count: 1})
+		return
+	}
+
+	minIdx := 0
+	for i := range t.entries {
+		if t.entries[i].Count < t.entries[minIdx].Count {
+			minIdx = i
+		}
+	}
+	m := t.entries[minIdx]
+	t.entries[minIdx] = Entry{Value: v, Count: m.Count + 1, Error: m.Count}
+}
+
+// Top returns the tracked entries sorted by Count in descending order.
+//
+// An entry's true count is at least Count-Error; Entry.Guaranteed is set
+// when Count-Error > n/k, which makes the entry a guaranteed heavy
+// hitter.
+func (t *TopK) Top() []Entry {
+	dst := append([]Entry{}, t.entries...)
+	for i := 1; i < len(dst); i++ {
+		for j := i; j > 0 && dst[j-1].Count < dst[j].Count; j-- {
+			dst[j-1], dst[j] = dst[j], dst[j-1]
+		}
+	}
+	threshold := t.n / uint64(t.k)
+	for i := range dst {
+		dst[i].Guaranteed = dst[i].Count-dst[i].Error > threshold
+	}
+	return dst
+}
+
+// Merge merges other into t, adding counters for shared values and
+// keeping the top-k entries by count.
+func (t *TopK) Merge(other *TopK) {
+	t.n += other.n
+
+	for _, oe := range other.entries {
+		found := false
+		for i := range t.entries {
+			if t.entries[i].Value == oe.Value {
+				t.entries[i].Count += oe.Count
+				t.entries[i].Error += oe.Error
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if len(t.entries) < t.k {
+			t.entries = append(t.entries, oe)
+			continue
+		}
+		minIdx := 0
+		for i := range t.entries {
+			if t.entries[i].Count < t.entries[minIdx].Count {
+				minIdx = i
+			}
+		}
+		if oe.Count > t.entries[minIdx].Count {
+			t.entries[minIdx] = oe
+		}
+	}
+
+	top := t.Top()
+	if len(top) > t.k {
+		top = top[:t.k]
+	}
+	t.entries = top
+}